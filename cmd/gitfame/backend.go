@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Backend abstracts the way gitfame talks to a repository so that the
+// analysis code does not care whether data comes from shelling out to the
+// git binary or from an in-process go-git implementation.
+type Backend interface {
+	LsTree(extensions, languages []string) []string
+	Blame(useCommitter bool, fileName string) (map[string][]string, map[string]int, error)
+	// ReadFile returns the contents of path as it exists in the analyzed
+	// commit, so callers (e.g. filterFiles's .gitignore/.gitattributes
+	// handling) don't fall back to reading the working directory, which may
+	// not match the commit or may not exist at all (bare repositories).
+	ReadFile(path string) ([]byte, error)
+	Close() error
+}
+
+// NewBackend builds the Backend selected by cfg.Backend ("exec" or
+// "go-git"). The mailmap is loaded after the backend exists, through the
+// backend itself, so .mailmap is resolved from cfg.Commit's tree rather than
+// the working directory (see LoadMailmap).
+func NewBackend(cfg Config) Backend {
+	switch cfg.Backend {
+	case "go-git":
+		b, err := NewGoGitBackend(cfg.Repository, cfg.Commit, nil)
+		if err != nil {
+			log.Fatalf("go-git backend: %v", err)
+		}
+		mailmap, err := LoadMailmap(b, cfg.MailmapFile, cfg.NoMailmap)
+		if err != nil {
+			log.Fatalf("load mailmap: %v", err)
+		}
+		b.mailmap = mailmap
+		return b
+	case "exec", "":
+		b := NewExecBackend(cfg.Repository, cfg.Commit, nil)
+		mailmap, err := LoadMailmap(b, cfg.MailmapFile, cfg.NoMailmap)
+		if err != nil {
+			log.Fatalf("load mailmap: %v", err)
+		}
+		b.mailmap = mailmap
+		return b
+	default:
+		log.Fatalf("unsupported backend %q, want exec or go-git", cfg.Backend)
+		return nil
+	}
+}
+
+// ExecBackend shells out to the git binary, one fork+exec per request, except
+// for tree listing and single-file reads, which are served from a
+// persistent `git cat-file --batch` process (see batchreader.go) once one
+// can be started. Blame is deliberately NOT on this batch process: git has
+// no batch or interactive protocol for computing blame, only the
+// object-content lookups cat-file answers, so Blame still runs as one
+// fork+exec per file (see GitBlame's doc comment in main.go).
+type ExecBackend struct {
+	rep     string
+	commit  string
+	cat     *batchReader // nil if the batch process could not be started
+	mailmap *Mailmap
+}
+
+func NewExecBackend(rep, commit string, mailmap *Mailmap) *ExecBackend {
+	cat, err := newBatchReader(rep)
+	if err != nil {
+		cat = nil
+	}
+	return &ExecBackend{rep: rep, commit: commit, cat: cat, mailmap: mailmap}
+}
+
+func (b *ExecBackend) LsTree(extensions, languages []string) []string {
+	if b.cat == nil {
+		return GitLsTree(b.rep, b.commit, extensions, languages)
+	}
+
+	files, err := b.cat.LsTree(b.commit)
+	if err != nil {
+		log.Printf("batch ls-tree failed, falling back to git ls-tree: %v", err)
+		return GitLsTree(b.rep, b.commit, extensions, languages)
+	}
+
+	return filterByExtension(files, extensions, languages)
+}
+
+func (b *ExecBackend) Blame(useCommitter bool, fileName string) (map[string][]string, map[string]int, error) {
+	out := GitBlame(b.rep, b.commit, fileName)
+	return Blame(out, useCommitter, b.rep, b.commit, fileName, b.mailmap)
+}
+
+func (b *ExecBackend) ReadFile(path string) ([]byte, error) {
+	if b.cat != nil {
+		_, content, err := b.cat.object(b.commit + ":" + path)
+		if err == nil {
+			return content, nil
+		}
+	}
+	return GitShowFile(b.rep, b.commit, path)
+}
+
+func (b *ExecBackend) Close() error {
+	if b.cat == nil {
+		return nil
+	}
+	return b.cat.Close()
+}
+
+// GoGitBackend opens the repository once with git.PlainOpen and serves
+// LsTree/Blame requests in-process via go-git, avoiding a fork+exec per file.
+// It is meant for bare mirrors or read-only filesystems that do not have a
+// usable git binary.
+type GoGitBackend struct {
+	repo    *git.Repository
+	commit  *object.Commit
+	mailmap *Mailmap
+
+	// mu serializes Blame. go-git's filesystem object storage/cache is not
+	// safe for concurrent use, but BlameAll (pool.go) calls Backend.Blame
+	// from cfg.Jobs goroutines at once; ExecBackend is fine with that
+	// because each call is its own git subprocess, but git.Blame against
+	// the shared *object.Commit/*git.Repository here is not.
+	mu sync.Mutex
+}
+
+func NewGoGitBackend(rep, commit string, mailmap *Mailmap) (*GoGitBackend, error) {
+	repo, err := git.PlainOpen(rep)
+	if err != nil {
+		return nil, fmt.Errorf("open repository %q: %w", rep, err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return nil, fmt.Errorf("resolve revision %q: %w", commit, err)
+	}
+
+	c, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("load commit %q: %w", hash, err)
+	}
+
+	return &GoGitBackend{repo: repo, commit: c, mailmap: mailmap}, nil
+}
+
+func (b *GoGitBackend) LsTree(extensions, languages []string) []string {
+	tree, err := b.commit.Tree()
+	if err != nil {
+		log.Fatalf("read tree: %v", err)
+	}
+
+	var out []string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		out = append(out, f.Name)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("walk tree: %v", err)
+	}
+
+	return filterByExtension(out, extensions, languages)
+}
+
+func (b *GoGitBackend) ReadFile(path string) ([]byte, error) {
+	tree, err := b.commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree: %w", err)
+	}
+
+	f, err := tree.File(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", path, err)
+	}
+
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", path, err)
+	}
+
+	return []byte(contents), nil
+}
+
+func (b *GoGitBackend) Close() error {
+	return nil
+}
+
+func (b *GoGitBackend) Blame(useCommitter bool, fileName string) (map[string][]string, map[string]int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result, err := git.Blame(b.commit, fileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("blame %q: %w", fileName, err)
+	}
+
+	authors := make(map[string][]string)
+	commits := make(map[string]int)
+
+	// go-git's blame.Line only carries the author, not the committer, so
+	// --use-committer has no effect through this backend. Note that on
+	// blame.Line, Author is the email address and AuthorName is the
+	// display name (the reverse of what the names suggest).
+	for _, line := range result.Lines {
+		hash := line.Hash.String()
+		commits[hash]++
+		name, email := b.mailmap.Canonicalize(line.AuthorName, line.Author)
+		key := authorKey(name, email)
+		authors[key] = append(authors[key], hash)
+	}
+
+	return authors, commits, nil
+}
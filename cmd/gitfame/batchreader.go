@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// batchReader wraps a single long-running `git cat-file --batch` process and
+// lets callers look up objects by SHA or revision without paying a
+// fork+exec per request. This mirrors the batch_reader.go pattern used by
+// server-side Git tooling to serve many object lookups over one pipe.
+type batchReader struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	out   *bufio.Reader
+	mu    sync.Mutex
+}
+
+func newBatchReader(rep string) (*batchReader, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = rep
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cat-file stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("cat-file stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start cat-file --batch: %w", err)
+	}
+
+	return &batchReader{cmd: cmd, stdin: stdin, out: bufio.NewReader(stdout)}, nil
+}
+
+// Close terminates the batch process, closing stdin first so it can exit on
+// its own.
+func (r *batchReader) Close() error {
+	r.stdin.Close()
+	return r.cmd.Wait()
+}
+
+// object looks up a single object by SHA or revision, returning its type and
+// raw content. The batch protocol replies with a header line
+// "<sha> <type> <size>\n" followed by exactly size bytes and a trailing
+// newline, or "<rev> missing\n" if the object does not exist.
+func (r *batchReader) object(rev string) (objType string, content []byte, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := fmt.Fprintf(r.stdin, "%s\n", rev); err != nil {
+		return "", nil, fmt.Errorf("write %q to cat-file --batch: %w", rev, err)
+	}
+
+	header, err := r.out.ReadString('\n')
+	if err != nil {
+		return "", nil, fmt.Errorf("read cat-file header for %q: %w", rev, err)
+	}
+	header = strings.TrimSuffix(header, "\n")
+
+	fields := strings.Fields(header)
+	if len(fields) == 2 && fields[1] == "missing" {
+		return "", nil, fmt.Errorf("object %q missing", rev)
+	}
+	if len(fields) != 3 {
+		return "", nil, fmt.Errorf("unexpected cat-file header %q", header)
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return "", nil, fmt.Errorf("cat-file size %q: %w", fields[2], err)
+	}
+
+	content = make([]byte, size)
+	if _, err := io.ReadFull(r.out, content); err != nil {
+		return "", nil, fmt.Errorf("read cat-file body for %q: %w", rev, err)
+	}
+	if _, err := r.out.Discard(1); err != nil { // trailing '\n' after the object body
+		return "", nil, fmt.Errorf("read cat-file trailer for %q: %w", rev, err)
+	}
+
+	return fields[1], content, nil
+}
+
+// treeEntry is one line of a raw tree object.
+type treeEntry struct {
+	mode string
+	name string
+	hash string
+}
+
+func parseTree(content []byte) ([]treeEntry, error) {
+	var entries []treeEntry
+	for len(content) > 0 {
+		sp := bytes.IndexByte(content, ' ')
+		if sp < 0 {
+			return nil, fmt.Errorf("malformed tree entry: missing mode separator")
+		}
+		mode := string(content[:sp])
+		content = content[sp+1:]
+
+		nul := bytes.IndexByte(content, 0)
+		if nul < 0 {
+			return nil, fmt.Errorf("malformed tree entry: missing name terminator")
+		}
+		name := string(content[:nul])
+		content = content[nul+1:]
+
+		if len(content) < 20 {
+			return nil, fmt.Errorf("malformed tree entry: short hash")
+		}
+		hash := hex.EncodeToString(content[:20])
+		content = content[20:]
+
+		entries = append(entries, treeEntry{mode: mode, name: name, hash: hash})
+	}
+	return entries, nil
+}
+
+// walkTree recursively lists the path of every non-tree entry (blobs and
+// submodule gitlinks) reachable from the tree object identified by sha,
+// prefixing entries with dir.
+func (r *batchReader) walkTree(sha, dir string) ([]string, error) {
+	objType, content, err := r.object(sha)
+	if err != nil {
+		return nil, err
+	}
+	if objType != "tree" {
+		return nil, fmt.Errorf("object %q is a %s, not a tree", sha, objType)
+	}
+
+	entries, err := parseTree(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		path := e.name
+		if dir != "" {
+			path = dir + "/" + e.name
+		}
+
+		if e.mode == "40000" {
+			sub, err := r.walkTree(e.hash, path)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, sub...)
+			continue
+		}
+
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
+// LsTree lists every file path in the tree of commit, resolving commit to
+// its root tree first if it is not already a tree object.
+func (r *batchReader) LsTree(commit string) ([]string, error) {
+	objType, content, err := r.object(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	switch objType {
+	case "commit":
+		line, _, _ := bytes.Cut(content, []byte("\n"))
+		fields := bytes.Fields(line)
+		if len(fields) != 2 || string(fields[0]) != "tree" {
+			return nil, fmt.Errorf("commit %q: missing tree header", commit)
+		}
+		return r.walkTree(string(fields[1]), "")
+	case "tree":
+		return r.walkTree(commit, "")
+	default:
+		return nil, fmt.Errorf("revision %q resolved to a %s, not a commit or tree", commit, objType)
+	}
+}
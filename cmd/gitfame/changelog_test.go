@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Fixtures below mirror actual `git log
+// --pretty=format:%H%x00%an%x00%s%x00%b<recordSep>` output: each record ends
+// with changelogRecordSep, and the hash/author/subject/body fields within a
+// record are separated by changelogFieldSep.
+func TestParseChangelogOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []changelogEntry
+	}{
+		{
+			name: "single commit, no body",
+			in: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\x00Alice\x00feat: add widget\x00\x1e\n",
+			want: []changelogEntry{
+				{
+					Hash:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Author:  "Alice",
+					Subject: "feat: add widget",
+				},
+			},
+		},
+		{
+			name: "two commits, one references an issue",
+			in: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\x00Alice\x00fix: crash on empty input\x00closes #42\x1e\n" +
+				"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\x00Bob\x00docs: update readme\x00\x1e\n",
+			want: []changelogEntry{
+				{
+					Hash:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Author:  "Alice",
+					Subject: "fix: crash on empty input",
+					Issues:  []int{42},
+				},
+				{
+					Hash:    "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+					Author:  "Bob",
+					Subject: "docs: update readme",
+				},
+			},
+		},
+		{
+			name: "commit with a BREAKING CHANGE trailer",
+			in: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\x00Alice\x00feat: drop legacy flag\x00" +
+				"BREAKING CHANGE: --legacy is removed\x1e\n",
+			want: []changelogEntry{
+				{
+					Hash:     "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Author:   "Alice",
+					Subject:  "feat: drop legacy flag",
+					Breaking: true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseChangelogOutput(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseChangelogOutput() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// filterFiles drops files that the repository itself declares uninteresting:
+// anything matched by a .gitignore pattern, plus anything .gitattributes
+// marks linguist-vendored, linguist-generated, linguist-documentation, or
+// gitstats-ignore (unless the matching --include-* flag opts it back in).
+// This is more accurate than --exclude globs because it follows the
+// project's own declared conventions instead of a list the caller has to
+// maintain by hand.
+func filterFiles(backend Backend, files []string, cfg Config) []string {
+	ignored, err := loadGitignore(backend)
+	if err != nil {
+		ignored = gitignore.NewMatcher(nil)
+	}
+
+	attrs, err := loadGitAttributes(backend)
+	if err != nil {
+		attrs = &attributeMatcher{}
+	}
+
+	out := make([]string, 0, len(files))
+	for _, f := range files {
+		if ignored.Match(strings.Split(f, "/"), false) {
+			continue
+		}
+		if !cfg.IncludeVendored && attrs.Get(f, "linguist-vendored") == "true" {
+			continue
+		}
+		if !cfg.IncludeGenerated && attrs.Get(f, "linguist-generated") == "true" {
+			continue
+		}
+		if !cfg.IncludeDocs && attrs.Get(f, "linguist-documentation") == "true" {
+			continue
+		}
+		if attrs.Get(f, "gitstats-ignore") == "true" {
+			continue
+		}
+		out = append(out, f)
+	}
+
+	return out
+}
+
+// treeFilesNamed returns the path and content of every blob in backend's tree
+// whose basename is name, so callers can locate and read .gitignore/
+// .gitattributes files without walking the working directory (which may be
+// absent for a bare repository, or simply not match cfg.Commit).
+func treeFilesNamed(backend Backend, name string) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for _, p := range backend.LsTree(nil, nil) {
+		if path.Base(p) != name {
+			continue
+		}
+		content, err := backend.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		out[p] = content
+	}
+	return out, nil
+}
+
+// pathsByDepth returns the keys of files with shallower paths first, so a
+// file in a parent directory is always read before one in a subdirectory.
+func pathsByDepth(files map[string][]byte) []string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := strings.Count(paths[i], "/"), strings.Count(paths[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// loadGitignore builds a go-git gitignore.Matcher from every .gitignore blob
+// in the commit's tree, maintaining the same per-directory pattern stack
+// go-git itself uses: patterns are scoped to the directory containing the
+// file they came from, and later patterns (including negations with '!')
+// override earlier ones for the same path.
+func loadGitignore(backend Backend) (gitignore.Matcher, error) {
+	files, err := treeFilesNamed(backend, ".gitignore")
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []gitignore.Pattern
+	for _, p := range pathsByDepth(files) {
+		content := files[p]
+		dir := path.Dir(p)
+		var dirParts []string
+		if dir != "." {
+			dirParts = strings.Split(dir, "/")
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+				continue
+			}
+			patterns = append(patterns, gitignore.ParsePattern(line, dirParts))
+		}
+	}
+
+	return gitignore.NewMatcher(patterns), nil
+}
+
+// attrRule is one pattern line out of a .gitattributes file.
+type attrRule struct {
+	dir     string // directory containing the .gitattributes file, relative to the tree root ("" for the root itself)
+	pattern string
+	attrs   map[string]string // attribute name -> "true", "false", or an explicit value
+}
+
+// attributeMatcher resolves gitattributes values the same way git stacks
+// them: rules from every .gitattributes file that covers a path apply in
+// order, with the last matching rule for a given attribute winning.
+type attributeMatcher struct {
+	rules []attrRule
+}
+
+func loadGitAttributes(backend Backend) (*attributeMatcher, error) {
+	files, err := treeFilesNamed(backend, ".gitattributes")
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []attrRule
+	for _, p := range pathsByDepth(files) {
+		content := files[p]
+		dir := path.Dir(p)
+		if dir == "." {
+			dir = ""
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			fields := strings.Fields(line)
+			attrs := make(map[string]string, len(fields)-1)
+			for _, f := range fields[1:] {
+				switch {
+				case strings.HasPrefix(f, "-"):
+					attrs[f[1:]] = "false"
+				case strings.Contains(f, "="):
+					name, value, _ := strings.Cut(f, "=")
+					attrs[name] = value
+				default:
+					attrs[f] = "true"
+				}
+			}
+			rules = append(rules, attrRule{dir: dir, pattern: fields[0], attrs: attrs})
+		}
+	}
+
+	return &attributeMatcher{rules: rules}, nil
+}
+
+// Get returns the value attr resolves to for path, or "" if no rule sets it.
+func (m *attributeMatcher) Get(path, attr string) string {
+	value := ""
+	for _, r := range m.rules {
+		rel := path
+		if r.dir != "" {
+			if !strings.HasPrefix(path, r.dir+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(path, r.dir+"/")
+		}
+
+		matched, _ := filepath.Match(r.pattern, rel)
+		if !matched {
+			matched, _ = filepath.Match(r.pattern, filepath.Base(rel))
+		}
+		if !matched {
+			continue
+		}
+
+		if v, ok := r.attrs[attr]; ok {
+			value = v
+		}
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// commitHashRe recognizes a bare %H token in the NUL-split git log output,
+// distinguishing the start of the next commit record from a numstat line.
+var commitHashRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// commitChurn is one commit's contribution to the log-based aggregator: the
+// files it touched and how many lines it added/removed in each.
+type commitChurn struct {
+	Hash    string
+	Author  string
+	Email   string
+	Added   int
+	Removed int
+	Files   []string
+}
+
+// LogAggregate replaces the "ls-tree + blame every file" strategy with a
+// single streaming `git log --numstat -z` over cfg.Commit (which may be a
+// plain revision or an A..B range), honoring --since/--until. This is much
+// faster than blame when the caller only wants churn attribution over a
+// window, since it reads history once instead of the tree once per file.
+//
+// Unlike log_name_status.go in Forgejo, which pairs --name-status with a
+// separate --numstat pass, this reads --numstat alone: a numstat record
+// already carries the path alongside the added/removed counts, so a second
+// pass isn't needed here.
+func LogAggregate(cfg Config) (map[string]*Statistics, error) {
+	commits, err := gitLogNumstat(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mailmap, err := loadLogMailmap(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	type acc struct {
+		commits map[string]struct{}
+		files   map[string]struct{}
+		added   int
+		removed int
+	}
+	byAuthor := make(map[string]*acc)
+
+	for _, c := range commits {
+		name, email := mailmap.Canonicalize(c.Author, c.Email)
+		key := authorKey(name, email)
+		a, ok := byAuthor[key]
+		if !ok {
+			a = &acc{commits: make(map[string]struct{}), files: make(map[string]struct{})}
+			byAuthor[key] = a
+		}
+		a.commits[c.Hash] = struct{}{}
+		a.added += c.Added
+		a.removed += c.Removed
+		for _, f := range c.Files {
+			a.files[f] = struct{}{}
+		}
+	}
+
+	authors := make(map[string]*Statistics, len(byAuthor))
+	for key, a := range byAuthor {
+		authors[key] = &Statistics{
+			Lines:        a.added,
+			Commits:      len(a.commits),
+			Files:        len(a.files),
+			LinesAdded:   a.added,
+			LinesRemoved: a.removed,
+		}
+	}
+
+	return authors, nil
+}
+
+// loadLogMailmap builds the Mailmap used to canonicalize identities for the
+// log-based aggregator. It always goes through a disposable ExecBackend
+// regardless of cfg.Backend: cfg.Commit may be an A..B range here, which
+// GoGitBackend's eager revision resolution can't handle, and --backend
+// go-git is about the tree-at-a-commit blame path, not this one.
+func loadLogMailmap(cfg Config) (*Mailmap, error) {
+	if cfg.NoMailmap {
+		return nil, nil
+	}
+
+	// For an A..B or A...B range, .mailmap is read as of the range's right
+	// end (HEAD when that end is omitted).
+	rev := cfg.Commit
+	if _, right, ok := strings.Cut(rev, ".."); ok {
+		rev = strings.TrimPrefix(right, ".")
+		if rev == "" {
+			rev = "HEAD"
+		}
+	}
+
+	backend := NewExecBackend(cfg.Repository, rev, nil)
+	defer backend.Close()
+
+	return LoadMailmap(backend, cfg.MailmapFile, cfg.NoMailmap)
+}
+
+func gitLogNumstat(cfg Config) ([]commitChurn, error) {
+	args := []string{"log", "--numstat", "-z", "--pretty=format:%H%x00%an%x00%ae"}
+	if cfg.Since != "" {
+		args = append(args, "--since="+cfg.Since)
+	}
+	if cfg.Until != "" {
+		args = append(args, "--until="+cfg.Until)
+	}
+	args = append(args, cfg.Commit)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = cfg.Repository
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log --numstat: %w", err)
+	}
+
+	return parseNumstatOutput(b), nil
+}
+
+// parseNumstatOutput parses the raw output of `git log --numstat -z
+// --pretty=format:%H%x00%an%x00%ae`, split out from gitLogNumstat so the
+// parser can be exercised against a captured fixture without an actual git
+// subprocess.
+func parseNumstatOutput(b []byte) []commitChurn {
+	// Only numstat records are NUL-terminated by -z; the %H/%an/%ae header
+	// line and the blank lines around each numstat block are still
+	// newline-terminated. Treat '\n' and '\x00' as equivalent separators so
+	// the header fields aren't glued to the first numstat line of a commit.
+	tokens := strings.FieldsFunc(string(b), func(r rune) bool {
+		return r == '\n' || r == '\x00'
+	})
+
+	var commits []commitChurn
+	expectHash := true
+
+	for _, tok := range tokens {
+		if tok == "" {
+			continue
+		}
+
+		if expectHash {
+			commits = append(commits, commitChurn{Hash: tok})
+			expectHash = false
+			continue
+		}
+
+		cur := &commits[len(commits)-1]
+		if cur.Author == "" {
+			cur.Author = tok
+			continue
+		}
+		if cur.Email == "" {
+			cur.Email = tok
+			continue
+		}
+
+		// A bare 40-hex token where a numstat line is expected starts the
+		// next commit instead.
+		if commitHashRe.MatchString(tok) {
+			commits = append(commits, commitChurn{Hash: tok})
+			continue
+		}
+
+		// Otherwise this token is a numstat line: "<added>\t<removed>\t<path>".
+		fields := strings.SplitN(tok, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		cur.Added += added
+		cur.Removed += removed
+		cur.Files = append(cur.Files, fields[2])
+	}
+
+	return commits
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Fixtures below mirror actual `git log --numstat -z
+// --pretty=format:%H%x00%an%x00%ae` output: the %H/%an/%ae header is
+// newline-terminated, and only the numstat lines that follow are
+// NUL-terminated by -z.
+func TestParseNumstatOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []commitChurn
+	}{
+		{
+			name: "single commit, two files",
+			in: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\x00Alice\x00alice@example.com\n" +
+				"\n" +
+				"2\t0\tfile1.go\x003\t1\tfile2.go\x00\n",
+			want: []commitChurn{
+				{
+					Hash:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Author:  "Alice",
+					Email:   "alice@example.com",
+					Added:   5,
+					Removed: 1,
+					Files:   []string{"file1.go", "file2.go"},
+				},
+			},
+		},
+		{
+			name: "two commits",
+			in: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\x00Alice\x00alice@example.com\n" +
+				"\n" +
+				"2\t0\tfile1.go\x00\n" +
+				"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\x00Bob\x00bob@example.com\n" +
+				"\n" +
+				"5\t2\tfile1.go\x00\n",
+			want: []commitChurn{
+				{
+					Hash:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Author:  "Alice",
+					Email:   "alice@example.com",
+					Added:   2,
+					Removed: 0,
+					Files:   []string{"file1.go"},
+				},
+				{
+					Hash:    "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+					Author:  "Bob",
+					Email:   "bob@example.com",
+					Added:   5,
+					Removed: 2,
+					Files:   []string{"file1.go"},
+				},
+			},
+		},
+		{
+			name: "merge commit with no file changes",
+			in: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\x00Alice\x00alice@example.com\n" +
+				"\n" +
+				"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\x00Bob\x00bob@example.com\n" +
+				"\n" +
+				"1\t1\tfile1.go\x00\n",
+			want: []commitChurn{
+				{
+					Hash:   "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Author: "Alice",
+					Email:  "alice@example.com",
+				},
+				{
+					Hash:    "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+					Author:  "Bob",
+					Email:   "bob@example.com",
+					Added:   1,
+					Removed: 1,
+					Files:   []string{"file1.go"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseNumstatOutput([]byte(tt.in))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNumstatOutput() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
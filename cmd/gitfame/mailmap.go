@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// mailmapIdentity is what a commit/author identity canonicalizes to. An
+// empty Name or Email means the mailmap entry didn't specify one, so the
+// original value should be kept.
+type mailmapIdentity struct {
+	Name  string
+	Email string
+}
+
+// Mailmap canonicalizes (name, email) pairs using a repository's .mailmap
+// file, following git's mailmap grammar:
+//
+//	Proper Name <proper@email> Commit Name <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	<proper@email> <commit@email>
+//
+// A nil *Mailmap is valid and canonicalizes everything to itself, so callers
+// that don't care about mailmaps (or passed --no-mailmap) don't need to
+// special-case it.
+type Mailmap struct {
+	byNameEmail map[string]mailmapIdentity // key: name + "\x1f" + email
+	byEmail     map[string]mailmapIdentity
+}
+
+var mailmapBracketRe = regexp.MustCompile(`<([^<>]*)>`)
+
+// LoadMailmap reads .mailmap from backend's analyzed commit and, if set, an
+// additional --mailmap-file from disk, merging both into one Mailmap.
+// Passing noMailmap=true skips loading entirely and returns nil, disabling
+// canonicalization.
+//
+// The repository's own .mailmap is read via backend.ReadFile rather than off
+// the working directory: a plain os.ReadFile against the repo path silently
+// returns no mailmap for bare repositories and for --backend go-git
+// environments with no usable working tree, which is exactly the read-only
+// use case the go-git backend exists to serve.
+func LoadMailmap(backend Backend, extraFile string, noMailmap bool) (*Mailmap, error) {
+	if noMailmap {
+		return nil, nil
+	}
+
+	m := &Mailmap{
+		byNameEmail: make(map[string]mailmapIdentity),
+		byEmail:     make(map[string]mailmapIdentity),
+	}
+
+	// A missing .mailmap is not an error: most repos don't have one.
+	if b, err := backend.ReadFile(".mailmap"); err == nil {
+		m.parseBytes(b)
+	}
+
+	if extraFile != "" {
+		b, err := os.ReadFile(extraFile)
+		if err != nil {
+			return nil, fmt.Errorf("read mailmap file %q: %w", extraFile, err)
+		}
+		m.parseBytes(b)
+	}
+
+	return m, nil
+}
+
+func (m *Mailmap) parseBytes(b []byte) {
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m.parseLine(line)
+	}
+}
+
+func (m *Mailmap) parseLine(line string) {
+	brackets := mailmapBracketRe.FindAllStringSubmatchIndex(line, -1)
+	if len(brackets) != 2 {
+		// Forms with only a proper name and no commit email aren't enough
+		// to canonicalize a (name, email) pair, so skip them.
+		return
+	}
+
+	properEmail := line[brackets[0][2]:brackets[0][3]]
+	commitEmail := line[brackets[1][2]:brackets[1][3]]
+	properName := strings.TrimSpace(line[:brackets[0][0]])
+	commitName := strings.TrimSpace(line[brackets[0][1]:brackets[1][0]])
+
+	identity := mailmapIdentity{Name: properName, Email: properEmail}
+
+	if commitName != "" {
+		// Proper Name <proper@email> Commit Name <commit@email>
+		m.byNameEmail[commitName+"\x1f"+commitEmail] = identity
+		return
+	}
+
+	// Proper Name <proper@email> <commit@email>, or
+	// <proper@email> <commit@email> when properName is also empty.
+	m.byEmail[commitEmail] = identity
+}
+
+// Canonicalize resolves name/email against the mailmap, falling back to the
+// original value for whichever field the matching entry didn't override.
+func (m *Mailmap) Canonicalize(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+
+	if id, ok := m.byNameEmail[name+"\x1f"+email]; ok {
+		return coalesce(id.Name, name), coalesce(id.Email, email)
+	}
+	if id, ok := m.byEmail[email]; ok {
+		return coalesce(id.Name, name), coalesce(id.Email, email)
+	}
+	return name, email
+}
+
+func coalesce(preferred, fallback string) string {
+	if preferred != "" {
+		return preferred
+	}
+	return fallback
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -21,15 +22,15 @@ type L struct {
 	Extensions []string
 }
 
-func GitLog(rep, commit, fileName string) (string, string) {
+func GitLog(rep, commit, fileName string) (hash, name, email string, err error) {
 	// Construct the git log command with the desired format
-	cmd := exec.Command("git", "log", "--pretty=format:%H %an", commit, "--", fileName)
+	cmd := exec.Command("git", "log", "--pretty=format:%H%x00%an%x00%ae", commit, "--", fileName)
 	cmd.Dir = rep // Set the working directory to the repository path
 
 	// Execute the command and capture the output
 	b, err := cmd.Output()
 	if err != nil {
-		log.Fatalf("Failed to execute git log command: %v", err)
+		return "", "", "", fmt.Errorf("git log %s: %w", fileName, err)
 	}
 
 	// Convert the output bytes to a string
@@ -37,19 +38,27 @@ func GitLog(rep, commit, fileName string) (string, string) {
 	// Split the output into lines
 	lines := strings.Split(output, "\n")
 	if len(lines) == 0 {
-		log.Fatal("No output from git log command")
+		return "", "", "", fmt.Errorf("git log %s: no output", fileName)
 	}
 
-	// Split the first line into commit hash and author name
-	parts := strings.SplitN(lines[0], " ", 2)
-	if len(parts) < 2 {
-		log.Fatal("Unexpected format of git log output")
+	// Split the first line into commit hash, author name, and author email
+	parts := strings.SplitN(lines[0], "\x00", 3)
+	if len(parts) < 3 {
+		return "", "", "", fmt.Errorf("git log %s: unexpected format %q", fileName, lines[0])
 	}
 
-	// Return the commit hash and author name
-	return parts[0], parts[1]
+	return parts[0], parts[1], parts[2], nil
 }
 
+// GitBlame shells out to `git blame --porcelain` for a single file. Unlike
+// tree listing, which ExecBackend.LsTree serves from batchReader (keeping
+// GitLsTree only as its fallback), this cannot be rewired onto batchReader:
+// `git cat-file --batch`
+// only answers object-content lookups by SHA/revision, and git exposes no
+// equivalent interactive protocol that computes blame output without a
+// dedicated fork+exec per file. The per-file exec cost here is instead
+// amortized by running BlameAll's worker pool (see pool.go) across files
+// concurrently, rather than by process reuse.
 func GitBlame(rep, commit, name string) []string {
 	cmd := exec.Command("git", "blame", "--porcelain", commit, name)
 	cmd.Dir = rep
@@ -62,6 +71,18 @@ func GitBlame(rep, commit, name string) []string {
 	})
 }
 
+// GitShowFile reads the contents of path as it exists in commit, without
+// touching the working directory, via `git show <commit>:<path>`.
+func GitShowFile(rep, commit, path string) ([]byte, error) {
+	cmd := exec.Command("git", "show", commit+":"+path)
+	cmd.Dir = rep
+	b, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %w", commit, path, err)
+	}
+	return b, nil
+}
+
 func GitLsTree(rep, commit string, extensions, languages []string) []string {
 	cmd := exec.Command("git", "ls-tree", "-r", "--name-only", commit)
 	cmd.Dir = rep
@@ -76,23 +97,30 @@ func GitLsTree(rep, commit string, extensions, languages []string) []string {
 		return r == '\n'
 	})
 
-	ans := out
+	return filterByExtension(out, extensions, languages)
+}
 
+// filterByExtension narrows files down to the ones matching extensions,
+// resolving languages to their extensions first. With no extensions or
+// languages given, files is returned unchanged.
+func filterByExtension(files, extensions, languages []string) []string {
 	if len(languages) != 0 {
 		getLanguagesExtensions(languages, &extensions)
 	}
 
-	if len(extensions) != 0 {
-		e := make(map[string]struct{}, len(extensions))
-		for _, i := range extensions {
-			e[i] = struct{}{}
-		}
+	if len(extensions) == 0 {
+		return files
+	}
 
-		ans = make([]string, 0)
-		for _, i := range out {
-			if _, ok := e[filepath.Ext(i)]; ok {
-				ans = append(ans, i)
-			}
+	e := make(map[string]struct{}, len(extensions))
+	for _, i := range extensions {
+		e[i] = struct{}{}
+	}
+
+	ans := make([]string, 0)
+	for _, i := range files {
+		if _, ok := e[filepath.Ext(i)]; ok {
+			ans = append(ans, i)
 		}
 	}
 
@@ -122,15 +150,25 @@ func getLanguagesExtensions(languages []string, extensions *[]string) {
 }
 
 type Config struct {
-	Repository   string
-	Commit       string
-	OrderBy      string
-	UseCommitter bool
-	Format       string
-	Extensions   []string
-	Languages    []string
-	Exclude      []string
-	RestrictTo   []string
+	Repository       string
+	Commit           string
+	OrderBy          string
+	UseCommitter     bool
+	Format           string
+	Extensions       []string
+	Languages        []string
+	Exclude          []string
+	RestrictTo       []string
+	Backend          string
+	Jobs             int
+	IncludeVendored  bool
+	IncludeGenerated bool
+	IncludeDocs      bool
+	IssueURL         string
+	Since            string
+	Until            string
+	MailmapFile      string
+	NoMailmap        bool
 }
 
 func NewConfig() Config {
@@ -142,11 +180,21 @@ func NewConfig() Config {
 	set.StringVar(&cfg.Commit, "revision", "HEAD", "Set the commit hash or branch to analyze. Defaults to the HEAD of the current branch.")
 	set.StringVar(&cfg.OrderBy, "order-by", "lines", "Determine the sorting criterion of the output. Options are 'lines', 'commits', or 'files'. Defaults to 'lines'.")
 	set.BoolVar(&cfg.UseCommitter, "use-committer", false, "Use the committer instead of the author for generating statistics. Defaults to false, using the author.")
-	set.StringVar(&cfg.Format, "format", "tabular", "Specify the format of the output. Options are 'tabular', 'csv', 'json', or 'json-lines'. Defaults to 'tabular'.")
+	set.StringVar(&cfg.Format, "format", "tabular", "Specify the format of the output. Options are 'tabular', 'csv', 'json', 'json-lines', or 'changelog'. Defaults to 'tabular'.")
 	set.StringSliceVar(&cfg.Extensions, "extensions", []string{}, "Provide a list of file extensions to include in the analysis. Separate multiple extensions with commas. If empty, all extensions are included.")
 	set.StringSliceVar(&cfg.Languages, "languages", []string{}, "Specify a list of programming languages to include in the analysis. This filters files based on common extensions for the specified languages. Separate multiple languages with commas.")
 	set.StringSliceVar(&cfg.Exclude, "exclude", []string{}, "Define a set of glob patterns to exclude files from the analysis. Separate multiple patterns with commas.")
 	set.StringSliceVar(&cfg.RestrictTo, "restrict-to", []string{}, "Define a set of glob patterns to restrict the analysis to specific files. Separate multiple patterns with commas.")
+	set.StringVar(&cfg.Backend, "backend", "exec", "Select the Git access backend. Options are 'exec' (shell out to the git binary) or 'go-git' (in-process, no git binary required). Defaults to 'exec'.")
+	set.IntVar(&cfg.Jobs, "jobs", runtime.NumCPU(), "Number of files to blame concurrently. Defaults to the number of logical CPUs.")
+	set.BoolVar(&cfg.IncludeVendored, "include-vendored", false, "Include files .gitattributes marks linguist-vendored. Excluded by default.")
+	set.BoolVar(&cfg.IncludeGenerated, "include-generated", false, "Include files .gitattributes marks linguist-generated. Excluded by default.")
+	set.BoolVar(&cfg.IncludeDocs, "include-docs", false, "Include files .gitattributes marks linguist-documentation. Excluded by default.")
+	set.StringVar(&cfg.IssueURL, "issue-url", "", "Base URL used to turn issue references into links in 'changelog' format output, e.g. https://github.com/org/repo/issues.")
+	set.StringVar(&cfg.Since, "since", "", "Only consider commits more recent than this date. Switches to the log-based aggregator; see --format=changelog for a related range-based view.")
+	set.StringVar(&cfg.Until, "until", "", "Only consider commits older than this date. Switches to the log-based aggregator.")
+	set.StringVar(&cfg.MailmapFile, "mailmap-file", "", "Additional .mailmap-format file to load on top of the repository's own .mailmap, for canonicalizing author identities.")
+	set.BoolVar(&cfg.NoMailmap, "no-mailmap", false, "Disable .mailmap canonicalization and group authors by their raw name/email as recorded in each commit.")
 
 	err := set.Parse(os.Args[1:])
 	if err != nil {
@@ -158,9 +206,15 @@ func NewConfig() Config {
 
 type AuthorJSON struct {
 	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
 	Lines   int    `json:"lines"`
 	Commits int    `json:"commits"`
 	Files   int    `json:"files"`
+
+	// Only set by the log-based aggregator (--since/--until or an A..B
+	// revision range); omitted from blame-based output.
+	LinesAdded   int `json:"lines_added,omitempty"`
+	LinesRemoved int `json:"lines_removed,omitempty"`
 }
 
 func FormatData(a AuthorSlice, format string) {
@@ -213,10 +267,13 @@ func formatJSON(a AuthorSlice) {
 	flatAuthors := make([]AuthorJSON, len(a.Slice))
 	for i, author := range a.Slice {
 		flatAuthors[i] = AuthorJSON{
-			Name:    author.Name,
-			Commits: author.Statistics.Commits,
-			Files:   author.Statistics.Files,
-			Lines:   author.Statistics.Lines,
+			Name:         author.Name,
+			Email:        author.Email,
+			Commits:      author.Statistics.Commits,
+			Files:        author.Statistics.Files,
+			Lines:        author.Statistics.Lines,
+			LinesAdded:   author.Statistics.LinesAdded,
+			LinesRemoved: author.Statistics.LinesRemoved,
 		}
 	}
 	b, err := json.Marshal(flatAuthors)
@@ -229,10 +286,13 @@ func formatJSON(a AuthorSlice) {
 func formatJSONlines(a AuthorSlice) {
 	for _, i := range a.Slice {
 		b, _ := json.Marshal(AuthorJSON{
-			Name:    i.Name,
-			Lines:   i.Statistics.Lines,
-			Commits: i.Statistics.Commits,
-			Files:   i.Statistics.Files,
+			Name:         i.Name,
+			Email:        i.Email,
+			Lines:        i.Statistics.Lines,
+			Commits:      i.Statistics.Commits,
+			Files:        i.Statistics.Files,
+			LinesAdded:   i.Statistics.LinesAdded,
+			LinesRemoved: i.Statistics.LinesRemoved,
 		})
 		os.Stdout.Write(b)
 		fmt.Println()
@@ -244,11 +304,17 @@ type Statistics struct {
 	Lines   int
 	Commits int
 	Files   int
+
+	// LinesAdded/LinesRemoved are only populated by the log-based aggregator
+	// (see logstats.go); the blame-based aggregator leaves them at zero.
+	LinesAdded   int
+	LinesRemoved int
 }
 
 type Author struct {
 	Statistics Statistics
 	Name       string
+	Email      string
 }
 
 type AuthorSlice struct {
@@ -349,20 +415,46 @@ func Exclude(files, exclude []string) []string {
 	return filteredFiles
 }
 
-func Blame(out []string, useCommitter bool, rep, commit, fileName string) (map[string][]string, map[string]int) {
+// authorKey packs a canonicalized (name, email) pair into the single string
+// the authors/authorSlice maps use as an identity key, so that "Alice
+// <a@x>" and "Alice Smith <a@x>" collapse to one row once run through a
+// Mailmap. splitAuthorKey reverses it for display.
+func authorKey(name, email string) string {
+	return name + "\x1f" + email
+}
+
+func splitAuthorKey(key string) (name, email string) {
+	name, email, ok := strings.Cut(key, "\x1f")
+	if !ok {
+		return key, ""
+	}
+	return name, email
+}
+
+func Blame(out []string, useCommitter bool, rep, commit, fileName string, mailmap *Mailmap) (map[string][]string, map[string]int, error) {
 	authors := make(map[string][]string)
 	commits := make(map[string]int)
 
 	if len(out) == 0 {
-		hash, a := GitLog(rep, commit, fileName)
+		hash, name, email, err := GitLog(rep, commit, fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		name, email = mailmap.Canonicalize(name, email)
 		commits[hash] = 0
-		authors[a] = append(authors[a], hash)
+		key := authorKey(name, email)
+		authors[key] = append(authors[key], hash)
+	}
+
+	nameField, mailField := "author", "author-mail"
+	if useCommitter {
+		nameField, mailField = "committer", "committer-mail"
 	}
 
 	isNextHash := true
 	itr := 0
 	var isWaitForAuthor bool
-	var lastHash string
+	var lastHash, name, mail string
 	for _, i := range out {
 		if isNextHash {
 			isNextHash = false
@@ -372,17 +464,21 @@ func Blame(out []string, useCommitter bool, rep, commit, fileName string) (map[s
 				commits[s[0]] += itr
 				isWaitForAuthor = true
 				lastHash = s[0]
+				name, mail = "", ""
 			}
 			itr--
 		} else if i[0] != '\t' && isWaitForAuthor {
-			s := strings.Split(i, " ")
-			if !useCommitter && s[0] == "author" {
-				name := i[len("author "):]
-				authors[name] = append(authors[name], lastHash)
-				isWaitForAuthor = false
-			} else if useCommitter && s[0] == "committer" {
-				name := i[len("committer "):]
-				authors[name] = append(authors[name], lastHash)
+			s := strings.SplitN(i, " ", 2)
+			switch s[0] {
+			case nameField:
+				name = i[len(nameField)+1:]
+			case mailField:
+				mail = strings.Trim(i[len(mailField)+1:], "<>")
+			}
+			if name != "" && mail != "" {
+				canonName, canonEmail := mailmap.Canonicalize(name, mail)
+				key := authorKey(canonName, canonEmail)
+				authors[key] = append(authors[key], lastHash)
 				isWaitForAuthor = false
 			}
 		} else if i[0] == '\t' {
@@ -390,7 +486,7 @@ func Blame(out []string, useCommitter bool, rep, commit, fileName string) (map[s
 		}
 	}
 
-	return authors, commits
+	return authors, commits, nil
 }
 
 func AuthorData(authors map[string]*Statistics, a map[string]map[string]struct{}, c, files map[string]int) {
@@ -411,10 +507,12 @@ func Sort(authors map[string]*Statistics, orderBy string) AuthorSlice {
 	var authorSlice AuthorSlice
 	authorSlice.orderBy = orderBy
 
-	for name, stats := range authors {
+	for key, stats := range authors {
+		name, email := splitAuthorKey(key)
 		authorSlice.Slice = append(authorSlice.Slice, Author{
 			Statistics: *stats,
 			Name:       name,
+			Email:      email,
 		})
 	}
 
@@ -425,8 +523,33 @@ func Sort(authors map[string]*Statistics, orderBy string) AuthorSlice {
 func main() {
 	cfg := NewConfig()
 
+	if cfg.Format == "changelog" {
+		if err := RunChangelog(cfg); err != nil {
+			log.Fatalf("changelog: %v", err)
+		}
+		return
+	}
+
+	// --since/--until or an A..B revision range have no meaning for a
+	// blame-of-the-tree-at-a-commit view, so route them through the
+	// streaming log-based aggregator instead.
+	if cfg.Since != "" || cfg.Until != "" || strings.Contains(cfg.Commit, "..") {
+		authors, err := LogAggregate(cfg)
+		if err != nil {
+			log.Fatalf("log aggregation: %v", err)
+		}
+		FormatData(Sort(authors, cfg.OrderBy), cfg.Format)
+		return
+	}
+
+	backend := NewBackend(cfg)
+	defer backend.Close()
+
 	// Получение списка файлов с учетом фильтров расширений и языков программирования
-	files := GitLsTree(cfg.Repository, cfg.Commit, cfg.Extensions, cfg.Languages)
+	files := backend.LsTree(cfg.Extensions, cfg.Languages)
+
+	// Исключение файлов по .gitignore/.gitattributes (vendored/generated/docs)
+	files = filterFiles(backend, files, cfg)
 
 	// Применение фильтров исключения
 	if len(cfg.Exclude) != 0 {
@@ -440,16 +563,9 @@ func main() {
 
 	// Инициализация структур для сбора данных
 	authors := make(map[string]*Statistics)
-	a1 := make(map[string]map[string]struct{}, 100)
-	c1 := make(map[string]int)
-	f1 := make(map[string]int)
 
-	// Обработка файлов для сбора статистики
-	for _, fileName := range files {
-		blameOutput := GitBlame(cfg.Repository, cfg.Commit, fileName)
-		a, c := Blame(blameOutput, cfg.UseCommitter, cfg.Repository, cfg.Commit, fileName)
-		UpdateData(a1, c1, f1, a, c)
-	}
+	// Обработка файлов для сбора статистики, не более cfg.Jobs одновременно
+	a1, c1, f1 := BlameAll(backend, cfg.UseCommitter, files, cfg.Jobs)
 
 	// Агрегация собранных данных
 	AuthorData(authors, a1, c1, f1)
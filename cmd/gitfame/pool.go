@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// blameOutcome is one file's contribution to the aggregate statistics, or the
+// error that made it unusable.
+type blameOutcome struct {
+	file    string
+	authors map[string][]string
+	commits map[string]int
+	err     error
+}
+
+// BlameAll blames files concurrently across jobs workers and merges the
+// per-file results into the a1/c1/f1 accumulators on a single reducer
+// goroutine, so UpdateData never needs to be safe for concurrent use. A file
+// that fails to blame is reported on stderr and skipped rather than aborting
+// the run. When stdout is not a terminal, a running "processed N/M" counter
+// is written to stderr.
+func BlameAll(backend Backend, useCommitter bool, files []string, jobs int) (map[string]map[string]struct{}, map[string]int, map[string]int) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	fileCh := make(chan string)
+	resultCh := make(chan blameOutcome)
+
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for file := range fileCh {
+				a, c, err := backend.Blame(useCommitter, file)
+				resultCh <- blameOutcome{file: file, authors: a, commits: c, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(fileCh)
+		for _, file := range files {
+			fileCh <- file
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	a1 := make(map[string]map[string]struct{}, 100)
+	c1 := make(map[string]int)
+	f1 := make(map[string]int)
+
+	showProgress := !isTerminal(os.Stdout)
+	total := len(files)
+	done := 0
+
+	for res := range resultCh {
+		done++
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "gitfame: skipping %s: %v\n", res.file, res.err)
+		} else {
+			UpdateData(a1, c1, f1, res.authors, res.commits)
+		}
+		if showProgress {
+			fmt.Fprintf(os.Stderr, "\rprocessed %d/%d files", done, total)
+		}
+	}
+	if showProgress {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	return a1, c1, f1
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}